@@ -7,6 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/eric-carlsson/crossplane-gen/pkg/composition"
 	"github.com/eric-carlsson/crossplane-gen/pkg/xrd"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/markers"
@@ -15,7 +16,8 @@ import (
 var (
 	// allGenerators maintains the list of all known generators
 	allGenerators = map[string]genall.Generator{
-		"xrd": xrd.Generator{},
+		"xrd":         xrd.Generator{},
+		"composition": composition.Generator{},
 	}
 
 	// allOutputRules defines the list of all known output rules
@@ -88,7 +90,10 @@ func main() {
 	crossplane-gen xrd paths=./apis/... output:stdout
 
 	# Generate XRDs with custom options
-	crossplane-gen xrd:maxDescLen=0 paths=./apis/... output:dir=./config/xrd`,
+	crossplane-gen xrd:maxDescLen=0 paths=./apis/... output:dir=./config/xrd
+
+	# Generate a matching XRD and Composition per kind in one invocation
+	crossplane-gen xrd composition paths=./apis/... output:dir=./package`,
 		RunE: func(_ *cobra.Command, rawOpts []string) error {
 			rt, err := genall.FromOptions(optionsRegistry, rawOpts)
 			if err != nil {
@@ -0,0 +1,122 @@
+// Package composition contains utilities for scaffolding Crossplane
+// Composition skeletons from the same Go XR types used to generate XRDs.
+package composition
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// Generator scaffolds Crossplane Composition objects for XR types.
+type Generator struct {
+	// IgnoreUnexportedFields indicates that we should skip unexported fields.
+	IgnoreUnexportedFields *bool `marker:",optional"`
+
+	// AllowDangerousTypes allows types which are usually omitted from CRD generation.
+	AllowDangerousTypes *bool `marker:",optional"`
+
+	// HeaderFile specifies the header text (e.g. license) to prepend to generated files.
+	HeaderFile string `marker:",optional"`
+
+	// Year specifies the year to substitute for " YEAR" in the header file.
+	Year string `marker:",optional"`
+}
+
+// CheckFilter returns the generator's node filter.
+func (Generator) CheckFilter() loader.NodeFilter {
+	return crd.Generator{}.CheckFilter()
+}
+
+// RegisterMarkers registers all markers needed by this generator.
+func (g Generator) RegisterMarkers(into *markers.Registry) error {
+	// Register all CRD markers since we need them to find XR kinds
+	crdGen := crd.Generator{}
+	if err := crdGen.RegisterMarkers(into); err != nil {
+		return err
+	}
+
+	// Register our own Crossplane-specific markers on top
+	return registerMarkers(into)
+}
+
+// Generate scaffolds a Composition for each XR kind found under the given roots.
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	parser := &crd.Parser{
+		Collector: ctx.Collector,
+		Checker:   ctx.Checker,
+	}
+
+	// Set parser options from generator config
+	if g.IgnoreUnexportedFields != nil {
+		parser.IgnoreUnexportedFields = *g.IgnoreUnexportedFields
+	}
+	if g.AllowDangerousTypes != nil {
+		parser.AllowDangerousTypes = *g.AllowDangerousTypes
+	}
+
+	crd.AddKnownTypes(parser)
+	for _, root := range ctx.Roots {
+		parser.NeedPackage(root)
+	}
+
+	metav1Pkg := crd.FindMetav1(ctx.Roots)
+	if metav1Pkg == nil {
+		return nil
+	}
+
+	kubeKinds := crd.FindKubeKinds(parser, metav1Pkg)
+	if len(kubeKinds) == 0 {
+		return nil
+	}
+
+	var headerText string
+
+	if g.HeaderFile != "" {
+		headerBytes, err := ctx.ReadFile(g.HeaderFile)
+		if err != nil {
+			return err
+		}
+		headerText = string(headerBytes)
+	}
+	headerText = strings.ReplaceAll(headerText, " YEAR", " "+g.Year)
+
+	// Scaffold a Composition for each kind
+	for _, groupKind := range kubeKinds {
+		parser.NeedCRDFor(groupKind, nil)
+		crdRaw := parser.CustomResourceDefinitions[groupKind]
+
+		var (
+			mode            string
+			steps           []PipelineStep
+			secretNamespace string
+		)
+
+		for _, root := range ctx.Roots {
+			m, s, ns, err := compositionMarkersFor(ctx.Collector, root, groupKind.Kind)
+			if err != nil {
+				return fmt.Errorf("failed to read composition markers for %s: %w", groupKind, err)
+			}
+			if m != "" || len(s) > 0 || ns != "" {
+				mode, steps, secretNamespace = m, s, ns
+				break
+			}
+		}
+
+		comp, err := BuildComposition(&crdRaw, mode, steps, secretNamespace)
+		if err != nil {
+			return fmt.Errorf("failed to build composition for %s: %w", groupKind, err)
+		}
+
+		fileName := fmt.Sprintf("%s_%s-composition.yaml", crdRaw.Spec.Group, crdRaw.Spec.Names.Plural)
+		if err := ctx.WriteYAML(fileName, headerText, []any{comp}); err != nil {
+			return fmt.Errorf("failed to write composition: %w", err)
+		}
+	}
+
+	return nil
+}
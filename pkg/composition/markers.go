@@ -0,0 +1,85 @@
+package composition
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	modeMarker                          = "crossplane:composition:mode"
+	pipelineStepMarker                  = "crossplane:composition:pipelineStep"
+	writeConnectionSecretToNamespaceMkr = "crossplane:composition:writeConnectionSecretToNamespace"
+)
+
+// Mode is the value of the +crossplane:composition:mode marker. It selects
+// spec.mode on the generated Composition.
+type Mode struct {
+	// Mode is the Composition mode, e.g. "Pipeline".
+	Mode string
+}
+
+// PipelineStep is the value of the +crossplane:composition:pipelineStep
+// marker. One marker is expected per desired pipeline step, in the order
+// they should run.
+type PipelineStep struct {
+	// Step is the name of the pipeline step.
+	Step string
+
+	// FunctionRef is the name of the Function this step invokes.
+	FunctionRef string
+}
+
+// WriteConnectionSecretToNamespace is the value of the
+// +crossplane:composition:writeConnectionSecretToNamespace marker.
+type WriteConnectionSecretToNamespace struct {
+	// Namespace is the namespace connection secrets should be written to.
+	Namespace string
+}
+
+// registerMarkers registers the markers this generator reads off XR types.
+func registerMarkers(into *markers.Registry) error {
+	defns := []*markers.Definition{
+		markers.Must(markers.MakeDefinition(modeMarker, markers.DescribesType, Mode{})),
+		markers.Must(markers.MakeDefinition(pipelineStepMarker, markers.DescribesType, PipelineStep{})),
+		markers.Must(markers.MakeDefinition(writeConnectionSecretToNamespaceMkr, markers.DescribesType, WriteConnectionSecretToNamespace{})),
+	}
+
+	for _, defn := range defns {
+		if err := into.Register(defn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compositionMarkersFor reads the +crossplane:composition:* markers for kind
+// out of root, returning the Composition mode, its pipeline steps (in
+// declaration order) and the connection secret namespace, if set.
+func compositionMarkersFor(col *markers.Collector, root *loader.Package, kind string) (mode string, steps []PipelineStep, secretNamespace string, err error) {
+	err = markers.EachType(col, root, func(info *markers.TypeInfo) {
+		if info.Name != kind {
+			return
+		}
+
+		if raw := info.Markers.Get(modeMarker); raw != nil {
+			if m, ok := raw.(Mode); ok {
+				mode = m.Mode
+			}
+		}
+
+		if raw := info.Markers.Get(writeConnectionSecretToNamespaceMkr); raw != nil {
+			if w, ok := raw.(WriteConnectionSecretToNamespace); ok {
+				secretNamespace = w.Namespace
+			}
+		}
+
+		for _, raw := range info.Markers[pipelineStepMarker] {
+			if s, ok := raw.(PipelineStep); ok {
+				steps = append(steps, s)
+			}
+		}
+	})
+
+	return mode, steps, secretNamespace, err
+}
@@ -0,0 +1,82 @@
+package composition
+
+import (
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane/v2/apis/apiextensions/v1"
+)
+
+// BuildComposition builds a skeleton Composition for the XR described by crd,
+// using the Crossplane-specific markers collected for its Go type.
+func BuildComposition(crd *apiextensionsv1.CustomResourceDefinition, mode string, steps []PipelineStep, secretNamespace string) (*xpv1.Composition, error) {
+	compositeTypeRef, err := compositeTypeRef(crd)
+	if err != nil {
+		return nil, err
+	}
+
+	comp := &xpv1.Composition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: xpv1.SchemeGroupVersion.String(),
+			Kind:       xpv1.CompositionKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   compositionName(crd),
+			Labels: compositionLabels(crd),
+		},
+		Spec: xpv1.CompositionSpec{
+			CompositeTypeRef: compositeTypeRef,
+		},
+	}
+
+	switch mode {
+	case "", string(xpv1.CompositionModePipeline):
+		comp.Spec.Mode = xpv1.CompositionModePipeline
+	default:
+		return nil, fmt.Errorf("unsupported composition mode %q: only %q is supported", mode, xpv1.CompositionModePipeline)
+	}
+
+	for _, step := range steps {
+		comp.Spec.Pipeline = append(comp.Spec.Pipeline, xpv1.PipelineStep{
+			Step:        step.Step,
+			FunctionRef: xpv1.FunctionReference{Name: step.FunctionRef},
+		})
+	}
+
+	if secretNamespace != "" {
+		comp.Spec.WriteConnectionSecretsToNamespace = &secretNamespace
+	}
+
+	return comp, nil
+}
+
+// compositeTypeRef derives spec.compositeTypeRef from the XR's CRD, preferring
+// its storage version since that's the version Crossplane expects compositions
+// to target.
+func compositeTypeRef(crd *apiextensionsv1.CustomResourceDefinition) (xpv1.TypeReference, error) {
+	for _, ver := range crd.Spec.Versions {
+		if ver.Storage {
+			return xpv1.TypeReference{
+				APIVersion: crd.Spec.Group + "/" + ver.Name,
+				Kind:       crd.Spec.Names.Kind,
+			}, nil
+		}
+	}
+
+	return xpv1.TypeReference{}, fmt.Errorf("%s.%s has no storage version", crd.Spec.Names.Kind, crd.Spec.Group)
+}
+
+// compositionName derives a default Composition name from the XR's CRD.
+func compositionName(crd *apiextensionsv1.CustomResourceDefinition) string {
+	return fmt.Sprintf("%s.%s", strings.ToLower(crd.Spec.Names.Kind), crd.Spec.Group)
+}
+
+// compositionLabels derives the default labels applied to a generated Composition.
+func compositionLabels(crd *apiextensionsv1.CustomResourceDefinition) map[string]string {
+	return map[string]string{
+		"crossplane.io/xrd": fmt.Sprintf("%s.%s", crd.Spec.Names.Plural, crd.Spec.Group),
+	}
+}
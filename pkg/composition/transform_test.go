@@ -0,0 +1,80 @@
+package composition
+
+import (
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane/v2/apis/apiextensions/v1"
+)
+
+func testCRD() *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "xfoos.example.org"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.org",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:   "XFoo",
+				Plural: "xfoos",
+			},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    "v1alpha1",
+					Served:  true,
+					Storage: true,
+				},
+			},
+		},
+	}
+}
+
+func TestBuildComposition(t *testing.T) {
+	crd := testCRD()
+	steps := []PipelineStep{
+		{Step: "compose", FunctionRef: "function-patch-and-transform"},
+	}
+
+	comp, err := BuildComposition(crd, "", steps, "crossplane-system")
+	if err != nil {
+		t.Fatalf("BuildComposition() error = %v", err)
+	}
+
+	if comp.Name != "xfoo.example.org" {
+		t.Errorf("Name = %q, want %q", comp.Name, "xfoo.example.org")
+	}
+	if comp.Spec.Mode != xpv1.CompositionModePipeline {
+		t.Errorf("Spec.Mode = %q, want %q", comp.Spec.Mode, xpv1.CompositionModePipeline)
+	}
+	if comp.Spec.CompositeTypeRef.APIVersion != "example.org/v1alpha1" {
+		t.Errorf("Spec.CompositeTypeRef.APIVersion = %q, want %q", comp.Spec.CompositeTypeRef.APIVersion, "example.org/v1alpha1")
+	}
+	if comp.Spec.CompositeTypeRef.Kind != "XFoo" {
+		t.Errorf("Spec.CompositeTypeRef.Kind = %q, want %q", comp.Spec.CompositeTypeRef.Kind, "XFoo")
+	}
+	if len(comp.Spec.Pipeline) != 1 || comp.Spec.Pipeline[0].Step != "compose" {
+		t.Errorf("Spec.Pipeline = %+v, want one step named \"compose\"", comp.Spec.Pipeline)
+	}
+	if comp.Spec.WriteConnectionSecretsToNamespace == nil || *comp.Spec.WriteConnectionSecretsToNamespace != "crossplane-system" {
+		t.Errorf("Spec.WriteConnectionSecretsToNamespace = %v, want \"crossplane-system\"", comp.Spec.WriteConnectionSecretsToNamespace)
+	}
+}
+
+func TestBuildCompositionUnsupportedMode(t *testing.T) {
+	crd := testCRD()
+
+	_, err := BuildComposition(crd, "Resources", nil, "")
+	if err == nil {
+		t.Fatal("BuildComposition() error = nil, want error for unsupported mode")
+	}
+}
+
+func TestBuildCompositionNoStorageVersion(t *testing.T) {
+	crd := testCRD()
+	crd.Spec.Versions[0].Storage = false
+
+	_, err := BuildComposition(crd, "", nil, "")
+	if err == nil {
+		t.Fatal("BuildComposition() error = nil, want error for a CRD with no storage version")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"sigs.k8s.io/controller-tools/pkg/crd"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/loader"
@@ -32,6 +33,27 @@ type Generator struct {
 
 	// Year specifies the year to substitute for " YEAR" in the header file.
 	Year string `marker:",optional"`
+
+	// APIVersion specifies the Crossplane apiextensions.crossplane.io API version
+	// to emit XRDs as, either "v1" or "v2". Defaults to "v2".
+	APIVersion string `marker:"apiVersion,optional"`
+
+	// FromCRDs specifies a glob of pre-generated CRD YAML manifests to use as input
+	// instead of loading Go source under paths=. Mutually exclusive with paths=.
+	FromCRDs string `marker:"fromCRDs,optional"`
+
+	// Versions, if set, restricts the CRD versions emitted on the XRD to this
+	// allow-list, pruning any other version present on the Go type.
+	Versions []string `marker:"versions,optional"`
+
+	// PreserveUnknownFields overrides x-kubernetes-preserve-unknown-fields on
+	// every version's schema in the emitted XRD.
+	PreserveUnknownFields *bool `marker:"preserveUnknownFields,optional"`
+
+	// Lint, if true, runs the full generation pipeline but writes nothing,
+	// instead validating that every XR satisfies Crossplane's conventions
+	// and exiting with an error if any don't.
+	Lint *bool `marker:"lint,optional"`
 }
 
 // CheckFilter returns the generator's node filter.
@@ -42,7 +64,13 @@ func (Generator) CheckFilter() loader.NodeFilter {
 // RegisterMarkers registers all markers needed by this generator.
 func (g Generator) RegisterMarkers(into *markers.Registry) error {
 	// Register all CRD markers since we need them for parsing
-	return crd.Generator{}.RegisterMarkers(into)
+	crdGen := crd.Generator{}
+	if err := crdGen.RegisterMarkers(into); err != nil {
+		return err
+	}
+
+	// Register our own Crossplane-specific markers on top
+	return registerCrossplaneMarkers(into)
 }
 
 // removeEmptyStatus removes the status field from the XRD output
@@ -53,6 +81,13 @@ func removeXRDStatus(obj map[string]any) error {
 
 // Generate generates XRD resources.
 func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	if g.FromCRDs != "" {
+		if len(ctx.Roots) > 0 {
+			return fmt.Errorf("xrd:fromCRDs is mutually exclusive with paths=; specify only one input source")
+		}
+		return g.generateFromCRDs(ctx)
+	}
+
 	parser := &crd.Parser{
 		Collector: ctx.Collector,
 		Checker:   ctx.Checker,
@@ -84,46 +119,180 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 		return nil
 	}
 
-	var headerText string
-
-	if g.HeaderFile != "" {
-		headerBytes, err := ctx.ReadFile(g.HeaderFile)
-		if err != nil {
-			return err
-		}
-		headerText = string(headerBytes)
+	headerText, err := g.header(ctx)
+	if err != nil {
+		return err
 	}
-	headerText = strings.ReplaceAll(headerText, " YEAR", " "+g.Year)
 
 	// Generate XRDs for each kind
 	for _, groupKind := range kubeKinds {
 		parser.NeedCRDFor(groupKind, g.MaxDescLen)
 		crdRaw := parser.CustomResourceDefinitions[groupKind]
 
-		// Validate storage version
-		hasStorage := false
-		for _, ver := range crdRaw.Spec.Versions {
-			if ver.Storage {
-				hasStorage = true
-				break
+		opts := XRDv1Options{CommonOptions: CommonOptions{Versions: g.versionOptions()}}
+
+		// Gather options from markers on the Go type
+		var managed bool
+		for _, root := range ctx.Roots {
+			overrides, err := versionOverridesFor(ctx.Collector, root, groupKind.Kind)
+			if err != nil {
+				return fmt.Errorf("failed to read version markers for %s: %w", groupKind, err)
+			}
+			if len(overrides) > 0 {
+				opts.Versions.Overrides = overrides
+			}
+
+			immutableFields, err := immutableFieldsFor(ctx.Collector, root, groupKind.Kind)
+			if err != nil {
+				return fmt.Errorf("failed to read immutable markers for %s: %w", groupKind, err)
+			}
+			if len(immutableFields) > 0 {
+				opts.Versions.ImmutableFields = immutableFields
+			}
+
+			metadata, err := metadataFor(ctx.Collector, root, groupKind.Kind)
+			if err != nil {
+				return fmt.Errorf("failed to read metadata markers for %s: %w", groupKind, err)
+			}
+			if len(metadata.Annotations) > 0 {
+				opts.Metadata.Annotations = metadata.Annotations
+			}
+			if len(metadata.Labels) > 0 {
+				opts.Metadata.Labels = metadata.Labels
+			}
+
+			conversionWebhookURL, err := conversionWebhookFor(ctx.Collector, root, groupKind.Kind)
+			if err != nil {
+				return fmt.Errorf("failed to read conversion webhook marker for %s: %w", groupKind, err)
+			}
+			if conversionWebhookURL != "" {
+				opts.ConversionWebhookURL = conversionWebhookURL
+			}
+
+			isManaged, err := isManagedFor(ctx.Collector, root, groupKind.Kind)
+			if err != nil {
+				return fmt.Errorf("failed to read managed marker for %s: %w", groupKind, err)
+			}
+			managed = managed || isManaged
+
+			if g.APIVersion == "v1" {
+				claimNames, err := claimNamesFor(ctx.Collector, root, groupKind.Kind)
+				if err != nil {
+					return fmt.Errorf("failed to read claimNames marker for %s: %w", groupKind, err)
+				}
+				if claimNames != nil {
+					opts.ClaimNames = claimNames
+				}
+
+				policy, err := defaultCompositionUpdatePolicyFor(ctx.Collector, root, groupKind.Kind)
+				if err != nil {
+					return fmt.Errorf("failed to read defaultCompositionUpdatePolicy marker for %s: %w", groupKind, err)
+				}
+				if policy != "" {
+					opts.DefaultCompositionUpdatePolicy = policy
+				}
 			}
 		}
-		if !hasStorage {
-			return fmt.Errorf("XRD %s.%s must have at least one version with +kubebuilder:storageversion marker",
-				groupKind.Kind, groupKind.Group)
+
+		if g.Lint != nil && *g.Lint {
+			if err := lintXRD(&crdRaw, managed); err != nil {
+				return fmt.Errorf("xrd:lint failed for %s: %w", groupKind, err)
+			}
+			if _, err := ToXRD(g.APIVersion, &crdRaw, opts); err != nil {
+				return fmt.Errorf("xrd:lint failed for %s: %w", groupKind, err)
+			}
+			continue
 		}
 
-		// Convert CRD to XRD
-		xrd, err := CRDToXRDv2(&crdRaw)
+		if err := g.writeXRD(ctx, &crdRaw, opts, headerText); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateFromCRDs generates XRDs from pre-generated CRD YAML manifests, skipping
+// the Go loader entirely.
+func (g Generator) generateFromCRDs(ctx *genall.GenerationContext) error {
+	crds, err := loadCRDsFromGlob(g.FromCRDs)
+	if err != nil {
+		return err
+	}
+
+	headerText, err := g.header(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := XRDv1Options{CommonOptions: CommonOptions{Versions: g.versionOptions()}}
+
+	for i := range crds {
+		if g.Lint != nil && *g.Lint {
+			if err := lintXRD(&crds[i], false); err != nil {
+				return fmt.Errorf("xrd:lint failed for %s.%s: %w", crds[i].Spec.Names.Kind, crds[i].Spec.Group, err)
+			}
+			if _, err := ToXRD(g.APIVersion, &crds[i], opts); err != nil {
+				return fmt.Errorf("xrd:lint failed for %s.%s: %w", crds[i].Spec.Names.Kind, crds[i].Spec.Group, err)
+			}
+			continue
+		}
+
+		if err := g.writeXRD(ctx, &crds[i], opts, headerText); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// header computes the (YEAR-substituted) header text to prepend to generated files.
+func (g Generator) header(ctx *genall.GenerationContext) (string, error) {
+	var headerText string
+
+	if g.HeaderFile != "" {
+		headerBytes, err := ctx.ReadFile(g.HeaderFile)
 		if err != nil {
-			return fmt.Errorf("failed to convert CRD to XRD for %s: %w", groupKind, err)
+			return "", err
 		}
+		headerText = string(headerBytes)
+	}
 
-		fileName := fmt.Sprintf("%s_%s.yaml", crdRaw.Spec.Group, crdRaw.Spec.Names.Plural)
-		if err := ctx.WriteYAML(fileName, headerText, []any{xrd}, genall.WithTransform(removeXRDStatus)); err != nil {
-			return fmt.Errorf("failed to write XRD: %w", err)
+	return strings.ReplaceAll(headerText, " YEAR", " "+g.Year), nil
+}
+
+// versionOptions builds the generator-wide (non-marker) parts of VersionOptions.
+func (g Generator) versionOptions() VersionOptions {
+	return VersionOptions{
+		Allow:                 g.Versions,
+		PreserveUnknownFields: g.PreserveUnknownFields,
+	}
+}
+
+// writeXRD validates, converts and writes a single CRD as an XRD.
+func (g Generator) writeXRD(ctx *genall.GenerationContext, crdRaw *apiextensionsv1.CustomResourceDefinition, opts XRDv1Options, headerText string) error {
+	// Validate storage version
+	hasStorage := false
+	for _, ver := range crdRaw.Spec.Versions {
+		if ver.Storage {
+			hasStorage = true
+			break
 		}
 	}
+	if !hasStorage {
+		return fmt.Errorf("XRD %s.%s must have at least one version with +kubebuilder:storageversion marker",
+			crdRaw.Spec.Names.Kind, crdRaw.Spec.Group)
+	}
+
+	xrd, err := ToXRD(g.APIVersion, crdRaw, opts)
+	if err != nil {
+		return fmt.Errorf("failed to convert CRD to XRD for %s.%s: %w", crdRaw.Spec.Names.Kind, crdRaw.Spec.Group, err)
+	}
+
+	fileName := fmt.Sprintf("%s_%s.yaml", crdRaw.Spec.Group, crdRaw.Spec.Names.Plural)
+	if err := ctx.WriteYAML(fileName, headerText, []any{xrd}, genall.WithTransform(removeXRDStatus)); err != nil {
+		return fmt.Errorf("failed to write XRD: %w", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,73 @@
+package xrd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// loadCRDsFromGlob expands pattern and unmarshals every CRD YAML document it
+// matches, supporting multi-document files, so that CRDs authored or
+// generated outside of this repo's Go types can still be converted to XRDs.
+func loadCRDsFromGlob(pattern string) ([]apiextensionsv1.CustomResourceDefinition, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xrd:fromCRDs glob %q: %w", pattern, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("xrd:fromCRDs glob %q matched no files", pattern)
+	}
+
+	var crds []apiextensionsv1.CustomResourceDefinition
+	for _, path := range paths {
+		docs, err := readCRDDocuments(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRDs from %s: %w", path, err)
+		}
+		crds = append(crds, docs...)
+	}
+
+	return crds, nil
+}
+
+// readCRDDocuments reads every YAML document in path and unmarshals each into
+// a CustomResourceDefinition.
+func readCRDDocuments(path string) ([]apiextensionsv1.CustomResourceDefinition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var crds []apiextensionsv1.CustomResourceDefinition
+
+	reader := kyaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := yaml.Unmarshal(doc, &crd); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal CRD document: %w", err)
+		}
+
+		crds = append(crds, crd)
+	}
+
+	return crds, nil
+}
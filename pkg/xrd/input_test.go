@@ -0,0 +1,109 @@
+package xrd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testCRDYAML = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: xfoos.example.org
+spec:
+  group: example.org
+  names:
+    kind: XFoo
+    plural: xfoos
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+`
+
+const testCRDYAML2 = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: xbars.example.org
+spec:
+  group: example.org
+  names:
+    kind: XBar
+    plural: xbars
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+`
+
+func writeTestFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadCRDsFromGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "foo.yaml", testCRDYAML)
+	writeTestFile(t, dir, "bar.yaml", testCRDYAML2)
+
+	crds, err := loadCRDsFromGlob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		t.Fatalf("loadCRDsFromGlob() error = %v", err)
+	}
+	if len(crds) != 2 {
+		t.Fatalf("len(crds) = %d, want 2", len(crds))
+	}
+}
+
+func TestLoadCRDsFromGlobNoMatches(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := loadCRDsFromGlob(filepath.Join(dir, "*.yaml"))
+	if err == nil {
+		t.Fatal("loadCRDsFromGlob() error = nil, want error when the glob matches no files")
+	}
+}
+
+func TestLoadCRDsFromGlobInvalidPattern(t *testing.T) {
+	_, err := loadCRDsFromGlob("[")
+	if err == nil {
+		t.Fatal("loadCRDsFromGlob() error = nil, want error for an invalid glob pattern")
+	}
+}
+
+func TestReadCRDDocumentsMultiDoc(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "multi.yaml", testCRDYAML+"---\n"+testCRDYAML2)
+
+	crds, err := readCRDDocuments(path)
+	if err != nil {
+		t.Fatalf("readCRDDocuments() error = %v", err)
+	}
+	if len(crds) != 2 {
+		t.Fatalf("len(crds) = %d, want 2", len(crds))
+	}
+	if crds[0].Spec.Names.Kind != "XFoo" {
+		t.Errorf("crds[0].Spec.Names.Kind = %q, want %q", crds[0].Spec.Names.Kind, "XFoo")
+	}
+	if crds[1].Spec.Names.Kind != "XBar" {
+		t.Errorf("crds[1].Spec.Names.Kind = %q, want %q", crds[1].Spec.Names.Kind, "XBar")
+	}
+}
+
+func TestReadCRDDocumentsSkipsEmptyDocuments(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "sparse.yaml", "\n\n---\n"+testCRDYAML)
+
+	crds, err := readCRDDocuments(path)
+	if err != nil {
+		t.Fatalf("readCRDDocuments() error = %v", err)
+	}
+	if len(crds) != 1 {
+		t.Fatalf("len(crds) = %d, want 1", len(crds))
+	}
+}
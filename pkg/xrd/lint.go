@@ -0,0 +1,69 @@
+package xrd
+
+import (
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// lintXRD validates that crd satisfies Crossplane's conventions for an XR,
+// without converting or writing anything. managed indicates whether the XR
+// carries the +crossplane:xrd:managed marker.
+func lintXRD(crd *apiextensionsv1.CustomResourceDefinition, managed bool) error {
+	var problems []string
+
+	storageVer := storageVersion(crd)
+	if storageVer == nil {
+		problems = append(problems, "no version has +kubebuilder:storageversion")
+	}
+
+	if storageVer != nil {
+		schema := storageVer.Schema
+		if !hasProperty(schema, "status", "conditions") {
+			problems = append(problems, "storage version schema is missing status.conditions")
+		}
+		if managed && !hasProperty(schema, "spec", "forProvider") {
+			problems = append(problems, "storage version schema is missing spec.forProvider, required by +crossplane:xrd:managed")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// storageVersion returns the CRD version flagged as the storage version, or
+// nil if none is.
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) *apiextensionsv1.CustomResourceDefinitionVersion {
+	for i, ver := range crd.Spec.Versions {
+		if ver.Storage {
+			return &crd.Spec.Versions[i]
+		}
+	}
+	return nil
+}
+
+// hasProperty reports whether schema's OpenAPI v3 validation has a property
+// at the given dotted path, e.g. hasProperty(schema, "status", "conditions").
+func hasProperty(schema *apiextensionsv1.CustomResourceValidation, path ...string) bool {
+	if schema == nil || schema.OpenAPIV3Schema == nil {
+		return false
+	}
+
+	props := schema.OpenAPIV3Schema.Properties
+	for i, name := range path {
+		prop, ok := props[name]
+		if !ok {
+			return false
+		}
+		if i == len(path)-1 {
+			return true
+		}
+		props = prop.Properties
+	}
+
+	return false
+}
@@ -0,0 +1,323 @@
+package xrd
+
+import (
+	"reflect"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// claimNamesMarker is the name under which the ClaimNames marker is registered.
+	claimNamesMarker = "crossplane:xrd:claimNames"
+
+	// versionMarker is the name under which the VersionMarker marker is registered.
+	versionMarker = "crossplane:xrd:version"
+
+	// metadataAnnotationMarker is the name under which the MetadataAnnotation marker is registered.
+	metadataAnnotationMarker = "crossplane:xrd:metadata:annotation"
+
+	// metadataLabelMarker is the name under which the MetadataLabel marker is registered.
+	metadataLabelMarker = "crossplane:xrd:metadata:label"
+
+	// conversionWebhookMarker is the name under which the ConversionWebhook marker is registered.
+	conversionWebhookMarker = "crossplane:xrd:conversion:webhook"
+
+	// defaultCompositionUpdatePolicyMarker is the name under which the
+	// DefaultCompositionUpdatePolicy marker is registered.
+	defaultCompositionUpdatePolicyMarker = "crossplane:xrd:defaultCompositionUpdatePolicy"
+
+	// managedMarker is the name under which the Managed marker is registered.
+	managedMarker = "crossplane:xrd:managed"
+
+	// immutableMarker is the name under which the Immutable field marker is registered.
+	immutableMarker = "crossplane:xrd:immutable"
+)
+
+// ClaimNames is the value of the +crossplane:xrd:claimNames marker. It is only
+// consulted when generating XRD v1 output, since claim support was removed
+// from the v2 CompositeResourceDefinition API.
+type ClaimNames struct {
+	// Kind is the kind of the XRD's claim type, e.g. "Foo".
+	Kind string
+
+	// Plural is the plural name of the XRD's claim type, e.g. "foos".
+	Plural string
+
+	// ShortNames are short names for the claim type, e.g. ["fo"].
+	ShortNames []string `marker:",optional"`
+}
+
+// VersionMarker is the value of the repeatable +crossplane:xrd:version marker.
+// One is expected per CRD version that needs its Referenceable or deprecation
+// status overridden, since Crossplane's semantics for these don't always line
+// up with the underlying CRD version's +kubebuilder:storageversion/deprecated.
+type VersionMarker struct {
+	// Version is the name of the CRD version this override applies to, e.g. "v1".
+	Version string
+
+	// Referenceable, if true, marks this version referenceable. False has no
+	// effect: referenceable is then inferred from +kubebuilder:storageversion.
+	Referenceable bool `marker:",optional"`
+
+	// Deprecated marks this version deprecated with the given warning message.
+	Deprecated string `marker:",optional"`
+}
+
+// MetadataAnnotation is the value of the repeatable
+// +crossplane:xrd:metadata:annotation marker, used to add an annotation to
+// the generated XRD's metadata on top of whatever the CRD already carries.
+type MetadataAnnotation struct {
+	Key   string
+	Value string
+}
+
+// MetadataLabel is the value of the repeatable +crossplane:xrd:metadata:label
+// marker, used to add a label to the generated XRD's metadata.
+type MetadataLabel struct {
+	Key   string
+	Value string
+}
+
+// ConversionWebhook is the value of the +crossplane:xrd:conversion:webhook
+// marker, used to point the XRD's conversion strategy at a webhook instead of
+// whatever conversion strategy the underlying CRD carries.
+type ConversionWebhook struct {
+	// URL is the URL of the conversion webhook.
+	URL string
+}
+
+// DefaultCompositionUpdatePolicy is the value of the
+// +crossplane:xrd:defaultCompositionUpdatePolicy marker.
+type DefaultCompositionUpdatePolicy struct {
+	// Policy is either "Automatic" or "Manual".
+	Policy string
+}
+
+// Managed is the value of the +crossplane:xrd:managed marker, a presence-only
+// marker that flags an XR as wrapping a managed resource. xrd:lint=true uses
+// it to require spec.forProvider on the XR's schema.
+type Managed struct{}
+
+// Immutable is the value of the field-level +crossplane:xrd:immutable marker.
+// It's a presence-only marker; the marked field gets a
+// "self == oldSelf" x-kubernetes-validations rule on its OpenAPI schema.
+// Only fields declared directly on the XR kind's own Go type are picked up
+// (see immutableFieldsFor); the marker on a field of a nested type, such as
+// the spec struct, has no effect.
+type Immutable struct{}
+
+// registerCrossplaneMarkers registers the Crossplane-specific markers used by
+// this generator in addition to the markers delegated from crd.Generator.
+func registerCrossplaneMarkers(into *markers.Registry) error {
+	defns := []*markers.Definition{
+		markers.Must(markers.MakeDefinition(claimNamesMarker, markers.DescribesType, ClaimNames{})),
+		markers.Must(markers.MakeDefinition(versionMarker, markers.DescribesType, VersionMarker{})),
+		markers.Must(markers.MakeDefinition(metadataAnnotationMarker, markers.DescribesType, MetadataAnnotation{})),
+		markers.Must(markers.MakeDefinition(metadataLabelMarker, markers.DescribesType, MetadataLabel{})),
+		markers.Must(markers.MakeDefinition(conversionWebhookMarker, markers.DescribesType, ConversionWebhook{})),
+		markers.Must(markers.MakeDefinition(defaultCompositionUpdatePolicyMarker, markers.DescribesType, DefaultCompositionUpdatePolicy{})),
+		markers.Must(markers.MakeDefinition(managedMarker, markers.DescribesType, Managed{})),
+		markers.Must(markers.MakeDefinition(immutableMarker, markers.DescribesField, Immutable{})),
+	}
+
+	for _, defn := range defns {
+		if err := into.Register(defn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// claimNamesFor looks up the +crossplane:xrd:claimNames marker for the given
+// kind in root, returning nil if it isn't set.
+func claimNamesFor(col *markers.Collector, root *loader.Package, kind string) (*apiextensionsv1.CustomResourceDefinitionNames, error) {
+	var claimNames *apiextensionsv1.CustomResourceDefinitionNames
+
+	err := markers.EachType(col, root, func(info *markers.TypeInfo) {
+		if info.Name != kind {
+			return
+		}
+
+		raw := info.Markers.Get(claimNamesMarker)
+		if raw == nil {
+			return
+		}
+
+		cn, ok := raw.(ClaimNames)
+		if !ok {
+			return
+		}
+
+		claimNames = &apiextensionsv1.CustomResourceDefinitionNames{
+			Kind:       cn.Kind,
+			Plural:     cn.Plural,
+			ShortNames: cn.ShortNames,
+		}
+	})
+
+	return claimNames, err
+}
+
+// metadataFor collects the +crossplane:xrd:metadata:annotation and
+// +crossplane:xrd:metadata:label markers for the given kind in root.
+func metadataFor(col *markers.Collector, root *loader.Package, kind string) (Metadata, error) {
+	var md Metadata
+
+	err := markers.EachType(col, root, func(info *markers.TypeInfo) {
+		if info.Name != kind {
+			return
+		}
+
+		for _, raw := range info.Markers[metadataAnnotationMarker] {
+			if a, ok := raw.(MetadataAnnotation); ok {
+				if md.Annotations == nil {
+					md.Annotations = map[string]string{}
+				}
+				md.Annotations[a.Key] = a.Value
+			}
+		}
+
+		for _, raw := range info.Markers[metadataLabelMarker] {
+			if l, ok := raw.(MetadataLabel); ok {
+				if md.Labels == nil {
+					md.Labels = map[string]string{}
+				}
+				md.Labels[l.Key] = l.Value
+			}
+		}
+	})
+
+	return md, err
+}
+
+// conversionWebhookFor looks up the +crossplane:xrd:conversion:webhook marker
+// for the given kind in root, returning "" if it isn't set.
+func conversionWebhookFor(col *markers.Collector, root *loader.Package, kind string) (string, error) {
+	var url string
+
+	err := markers.EachType(col, root, func(info *markers.TypeInfo) {
+		if info.Name != kind {
+			return
+		}
+
+		if raw := info.Markers.Get(conversionWebhookMarker); raw != nil {
+			if cw, ok := raw.(ConversionWebhook); ok {
+				url = cw.URL
+			}
+		}
+	})
+
+	return url, err
+}
+
+// defaultCompositionUpdatePolicyFor looks up the
+// +crossplane:xrd:defaultCompositionUpdatePolicy marker for the given kind in
+// root, returning "" if it isn't set.
+func defaultCompositionUpdatePolicyFor(col *markers.Collector, root *loader.Package, kind string) (string, error) {
+	var policy string
+
+	err := markers.EachType(col, root, func(info *markers.TypeInfo) {
+		if info.Name != kind {
+			return
+		}
+
+		if raw := info.Markers.Get(defaultCompositionUpdatePolicyMarker); raw != nil {
+			if p, ok := raw.(DefaultCompositionUpdatePolicy); ok {
+				policy = p.Policy
+			}
+		}
+	})
+
+	return policy, err
+}
+
+// isManagedFor reports whether the given kind in root carries the
+// +crossplane:xrd:managed marker.
+func isManagedFor(col *markers.Collector, root *loader.Package, kind string) (bool, error) {
+	managed := false
+
+	err := markers.EachType(col, root, func(info *markers.TypeInfo) {
+		if info.Name != kind {
+			return
+		}
+
+		if info.Markers.Get(managedMarker) != nil {
+			managed = true
+		}
+	})
+
+	return managed, err
+}
+
+// immutableFieldsFor returns the JSON names of the fields on kind in root
+// that carry the field-level +crossplane:xrd:immutable marker.
+func immutableFieldsFor(col *markers.Collector, root *loader.Package, kind string) ([]string, error) {
+	var fields []string
+
+	err := markers.EachType(col, root, func(info *markers.TypeInfo) {
+		if info.Name != kind {
+			return
+		}
+
+		for _, field := range info.Fields {
+			if field.Markers.Get(immutableMarker) != nil {
+				fields = append(fields, jsonFieldName(field.Name, field.Tag))
+			}
+		}
+	})
+
+	return fields, err
+}
+
+// jsonFieldName returns the JSON property name controller-tools would give a
+// Go field: the name from its json tag, if any, falling back to an
+// initial-lowercased guess. The guess is wrong for names with a leading
+// initialism (e.g. "URL", "ID"), so the tag is always preferred when present.
+func jsonFieldName(name string, tag reflect.StructTag) string {
+	if jsonTag, ok := tag.Lookup("json"); ok {
+		if tagName, _, _ := strings.Cut(jsonTag, ","); tagName != "" {
+			return tagName
+		}
+	}
+
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// versionOverridesFor collects the +crossplane:xrd:version markers for the
+// given kind in root, keyed by the CRD version name they apply to.
+func versionOverridesFor(col *markers.Collector, root *loader.Package, kind string) (map[string]VersionOverride, error) {
+	overrides := map[string]VersionOverride{}
+
+	err := markers.EachType(col, root, func(info *markers.TypeInfo) {
+		if info.Name != kind {
+			return
+		}
+
+		for _, raw := range info.Markers[versionMarker] {
+			v, ok := raw.(VersionMarker)
+			if !ok {
+				continue
+			}
+
+			override := overrides[v.Version]
+			if v.Referenceable {
+				referenceable := true
+				override.Referenceable = &referenceable
+			}
+			if v.Deprecated != "" {
+				override.DeprecationWarning = v.Deprecated
+			}
+			overrides[v.Version] = override
+		}
+	})
+
+	return overrides, err
+}
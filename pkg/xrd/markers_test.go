@@ -0,0 +1,46 @@
+package xrd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONFieldName(t *testing.T) {
+	cases := map[string]struct {
+		name string
+		tag  reflect.StructTag
+		want string
+	}{
+		"NoTagLowercasesFirstRune": {
+			name: "Foo",
+			want: "foo",
+		},
+		"NoTagInitialismGuessIsWrong": {
+			name: "URL",
+			want: "uRL",
+		},
+		"TagOverridesInitialismGuess": {
+			name: `URL`,
+			tag:  `json:"url"`,
+			want: "url",
+		},
+		"TagNameWithOmitempty": {
+			name: "ID",
+			tag:  `json:"id,omitempty"`,
+			want: "id",
+		},
+		"TagWithNoNameFallsBackToGuess": {
+			name: "ID",
+			tag:  `json:",omitempty"`,
+			want: "iD",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := jsonFieldName(tc.name, tc.tag); got != tc.want {
+				t.Errorf("jsonFieldName(%q, %q) = %q, want %q", tc.name, tc.tag, got, tc.want)
+			}
+		})
+	}
+}
@@ -2,16 +2,153 @@ package xrd
 
 import (
 	"encoding/json"
+	"fmt"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	commonv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	xpv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	xpv2 "github.com/crossplane/crossplane/v2/apis/apiextensions/v2"
 )
 
+// VersionOverride carries the per-version overrides read from a
+// +crossplane:xrd:version marker on the XR's Go type.
+type VersionOverride struct {
+	// Referenceable, if set, overrides whether this version is referenceable
+	// instead of inferring it from +kubebuilder:storageversion.
+	Referenceable *bool
+
+	// DeprecationWarning, if set, marks this version deprecated with the given message.
+	DeprecationWarning string
+}
+
+// VersionOptions controls which CRD versions are emitted on an XRD, and how.
+type VersionOptions struct {
+	// Allow, if non-empty, restricts the versions emitted on the XRD to this
+	// allow-list, read from xrd:versions=. All other CRD versions are pruned.
+	Allow []string
+
+	// PreserveUnknownFields, if set, overrides x-kubernetes-preserve-unknown-fields
+	// on each emitted version's schema, read from xrd:preserveUnknownFields=.
+	PreserveUnknownFields *bool
+
+	// Overrides carries per-version Referenceable/deprecation overrides, keyed by
+	// version name, read from +crossplane:xrd:version markers.
+	Overrides map[string]VersionOverride
+
+	// ImmutableFields are the JSON names of fields (top-level or under spec) that
+	// should get a "self == oldSelf" x-kubernetes-validations rule, read from the
+	// field-level +crossplane:xrd:immutable marker.
+	ImmutableFields []string
+}
+
+// Metadata carries additional annotations/labels to add to an XRD's metadata
+// on top of whatever the underlying CRD already carries, read from the
+// +crossplane:xrd:metadata:annotation and +crossplane:xrd:metadata:label markers.
+type Metadata struct {
+	Annotations map[string]string
+	Labels      map[string]string
+}
+
+// CommonOptions carries the options that apply the same way regardless of
+// which Crossplane apiextensions API version an XRD targets.
+type CommonOptions struct {
+	// Versions controls which CRD versions are emitted, and how.
+	Versions VersionOptions
+
+	// Metadata carries additional annotations/labels for the XRD.
+	Metadata Metadata
+
+	// ConversionWebhookURL, if set, points the XRD's conversion strategy at a
+	// webhook, overriding whatever conversion strategy the CRD carries.
+	ConversionWebhookURL string
+}
+
+func mergeMaps(base, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return base
+	}
+
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+func webhookConversion(url string) *apiextensionsv1.CustomResourceConversion {
+	return &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig:             &apiextensionsv1.WebhookClientConfig{URL: &url},
+			ConversionReviewVersions: []string{"v1"},
+		},
+	}
+}
+
+// applyImmutable adds a "self == oldSelf" x-kubernetes-validations rule to
+// each of fields, checking both the schema's top-level properties and
+// spec's, since immutable fields are usually under spec.
+func applyImmutable(schema *apiextensionsv1.JSONSchemaProps, fields []string) *apiextensionsv1.JSONSchemaProps {
+	if len(fields) == 0 {
+		return schema
+	}
+
+	schema = schema.DeepCopy()
+	rule := apiextensionsv1.ValidationRule{Rule: "self == oldSelf", Message: "this field is immutable"}
+
+	for _, name := range fields {
+		if prop, ok := schema.Properties[name]; ok {
+			prop.XValidations = append(prop.XValidations, rule)
+			schema.Properties[name] = prop
+			continue
+		}
+
+		if spec, ok := schema.Properties["spec"]; ok {
+			if prop, ok := spec.Properties[name]; ok {
+				prop.XValidations = append(prop.XValidations, rule)
+				spec.Properties[name] = prop
+				schema.Properties["spec"] = spec
+			}
+		}
+	}
+
+	return schema
+}
+
+func (o VersionOptions) allowed(name string) bool {
+	if len(o.Allow) == 0 {
+		return true
+	}
+	for _, allowed := range o.Allow {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
 // CRDToXRDv2 converts a Kubernetes CustomResourceDefinition to a Crossplane CompositeResourceDefinition (v2).
-func CRDToXRDv2(crd *apiextensionsv1.CustomResourceDefinition) (*xpv2.CompositeResourceDefinition, error) {
+func CRDToXRDv2(crd *apiextensionsv1.CustomResourceDefinition, common CommonOptions) (*xpv2.CompositeResourceDefinition, error) {
+	versions := convertVersions(crd.Spec.Versions, common.Versions)
+
+	referenceable := false
+	for _, ver := range versions {
+		if ver.Referenceable {
+			referenceable = true
+			break
+		}
+	}
+	if err := validateXRDVersions(crd.Spec.Names.Kind, crd.Spec.Group, len(versions), referenceable); err != nil {
+		return nil, err
+	}
+
 	xrd := &xpv2.CompositeResourceDefinition{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: xpv2.SchemeGroupVersion.String(),
@@ -19,33 +156,62 @@ func CRDToXRDv2(crd *apiextensionsv1.CustomResourceDefinition) (*xpv2.CompositeR
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        crd.Name,
-			Annotations: crd.Annotations,
-			Labels:      crd.Labels,
+			Annotations: mergeMaps(crd.Annotations, common.Metadata.Annotations),
+			Labels:      mergeMaps(crd.Labels, common.Metadata.Labels),
 		},
 		Spec: xpv2.CompositeResourceDefinitionSpec{
 			Group:    crd.Spec.Group,
 			Names:    crd.Spec.Names,
-			Versions: convertVersions(crd.Spec.Versions),
+			Versions: versions,
 		},
 	}
 
-	// Set conversion strategy if present
-	if crd.Spec.Conversion != nil {
+	switch {
+	case common.ConversionWebhookURL != "":
+		xrd.Spec.Conversion = webhookConversion(common.ConversionWebhookURL)
+	case crd.Spec.Conversion != nil:
 		xrd.Spec.Conversion = crd.Spec.Conversion
 	}
 
 	return xrd, nil
 }
 
-func convertVersions(crdVersions []apiextensionsv1.CustomResourceDefinitionVersion) []xpv2.CompositeResourceDefinitionVersion {
+// validateXRDVersions guards against xrd:versions= filtering an XRD down to
+// no versions, or to a set that excludes the CRD's storage version: either
+// would otherwise silently produce a broken XRD instead of an error.
+func validateXRDVersions(kind, group string, count int, hasReferenceable bool) error {
+	if count == 0 {
+		return fmt.Errorf("%s.%s: xrd:versions leaves no versions on the XRD; check the version allow-list", kind, group)
+	}
+	if !hasReferenceable {
+		return fmt.Errorf("%s.%s: xrd:versions excludes the storage version; the XRD must have a referenceable version", kind, group)
+	}
+	return nil
+}
+
+func convertVersions(crdVersions []apiextensionsv1.CustomResourceDefinitionVersion, verOpts VersionOptions) []xpv2.CompositeResourceDefinitionVersion {
 	xrdVersions := make([]xpv2.CompositeResourceDefinitionVersion, 0, len(crdVersions))
 
 	for _, crdVer := range crdVersions {
+		if !verOpts.allowed(crdVer.Name) {
+			continue
+		}
+
+		override := verOpts.Overrides[crdVer.Name]
+
+		// Map storage=true to referenceable=true, unless overridden by a
+		// +crossplane:xrd:version marker: Crossplane's notion of referenceable
+		// doesn't necessarily line up with Kubernetes' notion of storage.
+		referenceable := crdVer.Storage
+		if override.Referenceable != nil {
+			referenceable = *override.Referenceable
+		}
+
 		xrdVer := xpv2.CompositeResourceDefinitionVersion{
 			Name:          crdVer.Name,
-			Referenceable: crdVer.Storage, // Map storage=true to referenceable=true
+			Referenceable: referenceable,
 			Served:        crdVer.Served,
-			Schema:        convertSchema(crdVer.Schema),
+			Schema:        convertSchema(crdVer.Schema, verOpts.PreserveUnknownFields, verOpts.ImmutableFields),
 		}
 
 		// Convert additional printer columns if present
@@ -53,10 +219,15 @@ func convertVersions(crdVersions []apiextensionsv1.CustomResourceDefinitionVersi
 			xrdVer.AdditionalPrinterColumns = crdVer.AdditionalPrinterColumns
 		}
 
-		// Convert deprecated flag
-		if crdVer.Deprecated {
-			xrdVer.Deprecated = &crdVer.Deprecated
+		// Convert deprecated flag, preferring a marker-supplied warning
+		if crdVer.Deprecated || override.DeprecationWarning != "" {
+			deprecated := true
+			xrdVer.Deprecated = &deprecated
 			xrdVer.DeprecationWarning = crdVer.DeprecationWarning
+			if override.DeprecationWarning != "" {
+				warning := override.DeprecationWarning
+				xrdVer.DeprecationWarning = &warning
+			}
 		}
 
 		xrdVersions = append(xrdVersions, xrdVer)
@@ -65,13 +236,20 @@ func convertVersions(crdVersions []apiextensionsv1.CustomResourceDefinitionVersi
 	return xrdVersions
 }
 
-func convertSchema(crdSchema *apiextensionsv1.CustomResourceValidation) *xpv2.CompositeResourceValidation {
+func convertSchema(crdSchema *apiextensionsv1.CustomResourceValidation, preserveUnknownFields *bool, immutableFields []string) *xpv2.CompositeResourceValidation {
 	if crdSchema == nil || crdSchema.OpenAPIV3Schema == nil {
 		return nil
 	}
 
+	schema := crdSchema.OpenAPIV3Schema
+	if preserveUnknownFields != nil {
+		schema = schema.DeepCopy()
+		schema.XPreserveUnknownFields = preserveUnknownFields
+	}
+	schema = applyImmutable(schema, immutableFields)
+
 	// Marshal schema, as CompositeResourceValidation requires runtime.RawExtension
-	raw, err := json.Marshal(crdSchema.OpenAPIV3Schema)
+	raw, err := json.Marshal(schema)
 	if err != nil {
 		return nil
 	}
@@ -80,3 +258,171 @@ func convertSchema(crdSchema *apiextensionsv1.CustomResourceValidation) *xpv2.Co
 		OpenAPIV3Schema: runtime.RawExtension{Raw: raw},
 	}
 }
+
+// XRDv1Options carries the v1-only XRD fields that have no equivalent in the
+// Go CRD type and so cannot be derived from convertVersions alone.
+type XRDv1Options struct {
+	CommonOptions
+
+	// ClaimNames populates spec.claimNames, read from the +crossplane:xrd:claimNames marker.
+	ClaimNames *apiextensionsv1.CustomResourceDefinitionNames
+
+	// DefaultCompositionRef populates spec.defaultCompositionRef.Name.
+	DefaultCompositionRef string
+
+	// EnforcedCompositionRef populates spec.enforcedCompositionRef.Name.
+	EnforcedCompositionRef string
+
+	// ConnectionSecretKeys populates spec.connectionSecretKeys.
+	ConnectionSecretKeys []string
+
+	// DefaultCompositionUpdatePolicy populates spec.defaultCompositionUpdatePolicy,
+	// read from the +crossplane:xrd:defaultCompositionUpdatePolicy marker.
+	DefaultCompositionUpdatePolicy string
+}
+
+// CRDToXRDv1 converts a Kubernetes CustomResourceDefinition to a Crossplane
+// CompositeResourceDefinition targeting apiextensions.crossplane.io/v1, for
+// users running Crossplane control planes that predate the v2 API.
+func CRDToXRDv1(crd *apiextensionsv1.CustomResourceDefinition, opts XRDv1Options) (*xpv1.CompositeResourceDefinition, error) {
+	versions := convertVersionsV1(crd.Spec.Versions, opts.Versions)
+
+	referenceable := false
+	for _, ver := range versions {
+		if ver.Referenceable {
+			referenceable = true
+			break
+		}
+	}
+	if err := validateXRDVersions(crd.Spec.Names.Kind, crd.Spec.Group, len(versions), referenceable); err != nil {
+		return nil, err
+	}
+
+	xrd := &xpv1.CompositeResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: xpv1.SchemeGroupVersion.String(),
+			Kind:       xpv1.CompositeResourceDefinitionKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        crd.Name,
+			Annotations: mergeMaps(crd.Annotations, opts.Metadata.Annotations),
+			Labels:      mergeMaps(crd.Labels, opts.Metadata.Labels),
+		},
+		Spec: xpv1.CompositeResourceDefinitionSpec{
+			Group:                crd.Spec.Group,
+			Names:                crd.Spec.Names,
+			Versions:             versions,
+			ClaimNames:           opts.ClaimNames,
+			ConnectionSecretKeys: opts.ConnectionSecretKeys,
+		},
+	}
+
+	switch {
+	case opts.ConversionWebhookURL != "":
+		xrd.Spec.Conversion = webhookConversion(opts.ConversionWebhookURL)
+	case crd.Spec.Conversion != nil:
+		xrd.Spec.Conversion = crd.Spec.Conversion
+	}
+
+	if opts.DefaultCompositionRef != "" {
+		xrd.Spec.DefaultCompositionRef = &xpv1.CompositionReference{Name: opts.DefaultCompositionRef}
+	}
+
+	if opts.EnforcedCompositionRef != "" {
+		xrd.Spec.EnforcedCompositionRef = &xpv1.CompositionReference{Name: opts.EnforcedCompositionRef}
+	}
+
+	if opts.DefaultCompositionUpdatePolicy != "" {
+		policy := commonv1.UpdatePolicy(opts.DefaultCompositionUpdatePolicy)
+		xrd.Spec.DefaultCompositionUpdatePolicy = &policy
+	}
+
+	return xrd, nil
+}
+
+func convertVersionsV1(crdVersions []apiextensionsv1.CustomResourceDefinitionVersion, verOpts VersionOptions) []xpv1.CompositeResourceDefinitionVersion {
+	xrdVersions := make([]xpv1.CompositeResourceDefinitionVersion, 0, len(crdVersions))
+
+	for _, crdVer := range crdVersions {
+		if !verOpts.allowed(crdVer.Name) {
+			continue
+		}
+
+		override := verOpts.Overrides[crdVer.Name]
+
+		// Map storage=true to referenceable=true, unless overridden by a
+		// +crossplane:xrd:version marker: Crossplane's notion of referenceable
+		// doesn't necessarily line up with Kubernetes' notion of storage.
+		referenceable := crdVer.Storage
+		if override.Referenceable != nil {
+			referenceable = *override.Referenceable
+		}
+
+		xrdVer := xpv1.CompositeResourceDefinitionVersion{
+			Name:          crdVer.Name,
+			Referenceable: referenceable,
+			Served:        crdVer.Served,
+			Schema:        convertSchemaV1(crdVer.Schema, verOpts.PreserveUnknownFields, verOpts.ImmutableFields),
+		}
+
+		// Convert additional printer columns if present
+		if len(crdVer.AdditionalPrinterColumns) > 0 {
+			xrdVer.AdditionalPrinterColumns = crdVer.AdditionalPrinterColumns
+		}
+
+		// Convert deprecated flag, preferring a marker-supplied warning
+		if crdVer.Deprecated || override.DeprecationWarning != "" {
+			deprecated := true
+			xrdVer.Deprecated = &deprecated
+			xrdVer.DeprecationWarning = crdVer.DeprecationWarning
+			if override.DeprecationWarning != "" {
+				warning := override.DeprecationWarning
+				xrdVer.DeprecationWarning = &warning
+			}
+		}
+
+		xrdVersions = append(xrdVersions, xrdVer)
+	}
+
+	return xrdVersions
+}
+
+func convertSchemaV1(crdSchema *apiextensionsv1.CustomResourceValidation, preserveUnknownFields *bool, immutableFields []string) *xpv1.CompositeResourceValidation {
+	if crdSchema == nil || crdSchema.OpenAPIV3Schema == nil {
+		return nil
+	}
+
+	schema := crdSchema.OpenAPIV3Schema
+	if preserveUnknownFields != nil {
+		schema = schema.DeepCopy()
+		schema.XPreserveUnknownFields = preserveUnknownFields
+	}
+	schema = applyImmutable(schema, immutableFields)
+
+	// Marshal schema, as CompositeResourceValidation requires runtime.RawExtension
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+
+	return &xpv1.CompositeResourceValidation{
+		OpenAPIV3Schema: runtime.RawExtension{Raw: raw},
+	}
+}
+
+// ToXRD converts a CustomResourceDefinition to a Crossplane
+// CompositeResourceDefinition targeting the given Crossplane apiextensions
+// API version ("v1" or "v2", defaulting to "v2"). opts.ClaimNames,
+// opts.DefaultCompositionRef, opts.EnforcedCompositionRef,
+// opts.ConnectionSecretKeys and opts.DefaultCompositionUpdatePolicy are only
+// consulted for "v1", since they have no v2 equivalent.
+func ToXRD(version string, crd *apiextensionsv1.CustomResourceDefinition, opts XRDv1Options) (any, error) {
+	switch version {
+	case "", "v2":
+		return CRDToXRDv2(crd, opts.CommonOptions)
+	case "v1":
+		return CRDToXRDv1(crd, opts)
+	default:
+		return nil, fmt.Errorf("unsupported xrd:apiVersion %q: must be \"v1\" or \"v2\"", version)
+	}
+}
@@ -0,0 +1,199 @@
+package xrd
+
+import (
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	xpv2 "github.com/crossplane/crossplane/v2/apis/apiextensions/v2"
+)
+
+func testCRD() *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "xfoos.example.org"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.org",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:   "XFoo",
+				Plural: "xfoos",
+			},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    "v1alpha1",
+					Served:  true,
+					Storage: true,
+				},
+			},
+		},
+	}
+}
+
+func TestCRDToXRDv2RoundTrip(t *testing.T) {
+	crd := testCRD()
+
+	xrd, err := CRDToXRDv2(crd, CommonOptions{})
+	if err != nil {
+		t.Fatalf("CRDToXRDv2() error = %v", err)
+	}
+
+	if xrd.Name != crd.Name {
+		t.Errorf("Name = %q, want %q", xrd.Name, crd.Name)
+	}
+	if xrd.Spec.Group != crd.Spec.Group {
+		t.Errorf("Spec.Group = %q, want %q", xrd.Spec.Group, crd.Spec.Group)
+	}
+	if xrd.Spec.Names.Kind != crd.Spec.Names.Kind {
+		t.Errorf("Spec.Names.Kind = %q, want %q", xrd.Spec.Names.Kind, crd.Spec.Names.Kind)
+	}
+	if len(xrd.Spec.Versions) != 1 {
+		t.Fatalf("len(Spec.Versions) = %d, want 1", len(xrd.Spec.Versions))
+	}
+	if !xrd.Spec.Versions[0].Referenceable {
+		t.Error("Spec.Versions[0].Referenceable = false, want true (storage version)")
+	}
+}
+
+func TestCRDToXRDv1RoundTrip(t *testing.T) {
+	crd := testCRD()
+	opts := XRDv1Options{
+		ClaimNames: &apiextensionsv1.CustomResourceDefinitionNames{
+			Kind:   "Foo",
+			Plural: "foos",
+		},
+		DefaultCompositionRef:          "my-composition",
+		DefaultCompositionUpdatePolicy: "Automatic",
+	}
+
+	xrd, err := CRDToXRDv1(crd, opts)
+	if err != nil {
+		t.Fatalf("CRDToXRDv1() error = %v", err)
+	}
+
+	if xrd.Name != crd.Name {
+		t.Errorf("Name = %q, want %q", xrd.Name, crd.Name)
+	}
+	if xrd.Spec.ClaimNames == nil || xrd.Spec.ClaimNames.Kind != "Foo" {
+		t.Errorf("Spec.ClaimNames = %+v, want Kind \"Foo\"", xrd.Spec.ClaimNames)
+	}
+	if xrd.Spec.DefaultCompositionRef == nil || xrd.Spec.DefaultCompositionRef.Name != "my-composition" {
+		t.Errorf("Spec.DefaultCompositionRef = %+v, want Name \"my-composition\"", xrd.Spec.DefaultCompositionRef)
+	}
+	if xrd.Spec.DefaultCompositionUpdatePolicy == nil || string(*xrd.Spec.DefaultCompositionUpdatePolicy) != "Automatic" {
+		t.Errorf("Spec.DefaultCompositionUpdatePolicy = %v, want \"Automatic\"", xrd.Spec.DefaultCompositionUpdatePolicy)
+	}
+}
+
+func TestCRDToXRDv2VersionFilterExcludesStorage(t *testing.T) {
+	crd := testCRD()
+
+	_, err := CRDToXRDv2(crd, CommonOptions{
+		Versions: VersionOptions{Allow: []string{"v1"}},
+	})
+	if err == nil {
+		t.Fatal("CRDToXRDv2() error = nil, want error for an allow-list excluding the storage version")
+	}
+}
+
+func TestCRDToXRDv1VersionFilterExcludesStorage(t *testing.T) {
+	crd := testCRD()
+
+	_, err := CRDToXRDv1(crd, XRDv1Options{
+		CommonOptions: CommonOptions{
+			Versions: VersionOptions{Allow: []string{"v1"}},
+		},
+	})
+	if err == nil {
+		t.Fatal("CRDToXRDv1() error = nil, want error for an allow-list excluding the storage version")
+	}
+}
+
+func TestValidateXRDVersions(t *testing.T) {
+	cases := map[string]struct {
+		count            int
+		hasReferenceable bool
+		wantErr          bool
+	}{
+		"NoVersions":           {count: 0, hasReferenceable: false, wantErr: true},
+		"NoReferenceable":      {count: 1, hasReferenceable: false, wantErr: true},
+		"ReferenceablePresent": {count: 1, hasReferenceable: true, wantErr: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validateXRDVersions("XFoo", "example.org", tc.count, tc.hasReferenceable)
+			if tc.wantErr != (err != nil) {
+				t.Errorf("validateXRDVersions() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyImmutable(t *testing.T) {
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"uRL": {Type: "string"},
+			"spec": {
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"iD": {Type: "string"},
+				},
+			},
+		},
+	}
+
+	got := applyImmutable(schema, []string{"uRL", "iD"})
+
+	top := got.Properties["uRL"]
+	if len(top.XValidations) != 1 || top.XValidations[0].Rule != "self == oldSelf" {
+		t.Errorf("top-level field uRL: XValidations = %+v, want one self == oldSelf rule", top.XValidations)
+	}
+
+	nested := got.Properties["spec"].Properties["iD"]
+	if len(nested.XValidations) != 1 || nested.XValidations[0].Rule != "self == oldSelf" {
+		t.Errorf("spec.iD: XValidations = %+v, want one self == oldSelf rule", nested.XValidations)
+	}
+}
+
+func TestToXRD(t *testing.T) {
+	crd := testCRD()
+
+	cases := map[string]struct {
+		version string
+		wantV1  bool
+		wantErr bool
+	}{
+		"DefaultsToV2": {version: ""},
+		"ExplicitV2":   {version: "v2"},
+		"ExplicitV1":   {version: "v1", wantV1: true},
+		"Unsupported":  {version: "v3", wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ToXRD(tc.version, crd, XRDv1Options{})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("ToXRD() error = nil, want non-nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ToXRD() error = %v", err)
+			}
+
+			switch got.(type) {
+			case *xpv1.CompositeResourceDefinition:
+				if !tc.wantV1 {
+					t.Errorf("ToXRD(%q) returned a v1 XRD, want v2", tc.version)
+				}
+			case *xpv2.CompositeResourceDefinition:
+				if tc.wantV1 {
+					t.Errorf("ToXRD(%q) returned a v2 XRD, want v1", tc.version)
+				}
+			default:
+				t.Errorf("ToXRD(%q) returned unexpected type %T", tc.version, got)
+			}
+		})
+	}
+}